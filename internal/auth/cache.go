@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// roleCacheSize bounds how many usernames' roles are kept in memory at
+// once; the least recently used entry is evicted once it's exceeded.
+const roleCacheSize = 4096
+
+// roleCache holds (username -> []*Role) entries for the auth_revision they
+// were computed at. The whole cache is invalidated the moment a caller
+// observes a newer revision, since a single bump can affect any username.
+type roleCache struct {
+	mu       sync.Mutex
+	revision int64
+	entries  *lru.Cache[string, []*Role]
+}
+
+func newRoleCache() *roleCache {
+	entries, _ := lru.New[string, []*Role](roleCacheSize)
+	return &roleCache{entries: entries}
+}
+
+// get returns the cached roles for username if the cache was last filled at
+// revision, reporting a miss otherwise.
+func (c *roleCache) get(username string, revision int64) ([]*Role, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.revision != revision {
+		return nil, false
+	}
+
+	return c.entries.Get(username)
+}
+
+// set stores roles for username at revision, purging stale entries first
+// if revision has moved on since the cache was last filled.
+func (c *roleCache) set(username string, revision int64, roles []*Role) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.revision != revision {
+		c.entries.Purge()
+		c.revision = revision
+	}
+
+	c.entries.Add(username, roles)
+}
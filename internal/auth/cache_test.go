@@ -0,0 +1,53 @@
+package auth
+
+import "testing"
+
+func TestRoleCache_GetSet(t *testing.T) {
+	c := newRoleCache()
+
+	if _, ok := c.get("alice", 1); ok {
+		t.Fatal("get() ok = true on an empty cache")
+	}
+
+	roles := []*Role{{ID: 1, Name: "admin"}}
+	c.set("alice", 1, roles)
+
+	got, ok := c.get("alice", 1)
+	if !ok {
+		t.Fatal("get() ok = false right after set()")
+	}
+	if len(got) != 1 || got[0].Name != "admin" {
+		t.Fatalf("get() = %v, want %v", got, roles)
+	}
+}
+
+func TestRoleCache_MissOnRevisionMismatch(t *testing.T) {
+	c := newRoleCache()
+	c.set("alice", 1, []*Role{{ID: 1, Name: "admin"}})
+
+	if _, ok := c.get("alice", 2); ok {
+		t.Fatal("get() ok = true for a revision newer than the one the cache was filled at")
+	}
+}
+
+func TestRoleCache_PurgesOnRevisionBump(t *testing.T) {
+	c := newRoleCache()
+	c.set("alice", 1, []*Role{{ID: 1, Name: "admin"}})
+	c.set("bob", 1, []*Role{{ID: 2, Name: "viewer"}})
+
+	// A set at a newer revision purges every entry from the stale revision,
+	// not just the username being set.
+	c.set("alice", 2, []*Role{{ID: 3, Name: "owner"}})
+
+	if _, ok := c.get("bob", 2); ok {
+		t.Fatal("get() ok = true for bob at the new revision, but the stale entry should have been purged")
+	}
+
+	got, ok := c.get("alice", 2)
+	if !ok {
+		t.Fatal("get() ok = false for alice at the new revision")
+	}
+	if len(got) != 1 || got[0].Name != "owner" {
+		t.Fatalf("get() = %v, want the entry just set at the new revision", got)
+	}
+}
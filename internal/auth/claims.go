@@ -1,11 +1,31 @@
 package auth
 
-import "context"
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
 
 type Claims struct {
 	ID          string `json:"id"`
 	Username    string `json:"username"`
 	DisplayName string `json:"displayName"`
+	// TokenID is the token's jti, used to check it against a Revoker.
+	TokenID string `json:"jti,omitempty"`
+	// Scopes narrows what the token's subject may do. CanI treats a token
+	// with no scopes like one carrying a single UserScope; CanIDo denies it.
+	Scopes Scopes `json:"scopes,omitempty"`
+}
+
+// newTokenID returns a random jti for a freshly minted token.
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
 }
 
 type ctxKey int
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	rpcstatus "google.golang.org/grpc/status"
+)
+
+// MethodPolicy maps a full gRPC method name (e.g. "/auth.Auth/Login") to the
+// permissions CanI must grant before its handler runs.
+type MethodPolicy map[string][]string
+
+// PolicyFunc returns the permissions fullMethod requires, given ctx (which
+// already carries the caller's Claims). A nil or empty result skips the
+// permission check, leaving authentication as the only gate.
+type PolicyFunc func(ctx context.Context, fullMethod string) []string
+
+// InterceptorOption customizes the interceptors returned by
+// UnaryServerInterceptor and StreamServerInterceptor.
+type InterceptorOption func(*interceptorConfig)
+
+type interceptorConfig struct {
+	publicMethods map[string]struct{}
+	policy        PolicyFunc
+}
+
+func newInterceptorConfig(opts []InterceptorOption) *interceptorConfig {
+	cfg := &interceptorConfig{publicMethods: make(map[string]struct{})}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithPublicMethods exempts the given full method names from token parsing
+// and permission checks entirely, e.g. "/auth.Auth/Login".
+func WithPublicMethods(methods ...string) InterceptorOption {
+	return func(cfg *interceptorConfig) {
+		for _, m := range methods {
+			cfg.publicMethods[m] = struct{}{}
+		}
+	}
+}
+
+// WithMethodPolicy enforces policy's required permissions via CanI once
+// Claims have been populated for the call.
+func WithMethodPolicy(policy MethodPolicy) InterceptorOption {
+	return WithPolicyFunc(func(_ context.Context, fullMethod string) []string {
+		return policy[fullMethod]
+	})
+}
+
+// WithPolicyFunc enforces fn's required permissions via CanI once Claims
+// have been populated for the call.
+func WithPolicyFunc(fn PolicyFunc) InterceptorOption {
+	return func(cfg *interceptorConfig) { cfg.policy = fn }
+}
+
+// authenticate extracts and verifies the bearer access token from ctx's
+// incoming metadata, checks it against the Revoker, and returns a context
+// carrying its Claims.
+func (s *Auth) authenticate(ctx context.Context) (context.Context, error) {
+	unauthenticated := rpcstatus.Error(codes.Unauthenticated, "Your credentials not valid. Please check your token and try again.")
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx, unauthenticated
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ctx, unauthenticated
+	}
+	token := strings.TrimPrefix(values[0], "Bearer ")
+
+	rules := []paseto.Rule{
+		paseto.NotExpired(),
+		paseto.ValidAt(time.Now()),
+	}
+
+	parser := paseto.MakeParser(rules)
+	t, err := parser.ParseV4Local(s.aKey, token, nil)
+	if err != nil {
+		return ctx, unauthenticated
+	}
+
+	claims := new(Claims)
+	if err := t.Get("profile", claims); err != nil {
+		return ctx, unauthenticated
+	}
+
+	if claims.TokenID != "" {
+		revoked, err := s.revoker.IsRevoked(ctx, claims.TokenID)
+		if err != nil {
+			return ctx, err
+		}
+		if revoked {
+			return ctx, unauthenticated
+		}
+	}
+
+	return ContextWithClaims(ctx, claims), nil
+}
+
+// UnaryServerInterceptor parses the bearer access token on every unary call
+// not listed in WithPublicMethods, injects its Claims into context, and
+// enforces whatever permissions WithMethodPolicy/WithPolicyFunc require for
+// the method before the handler runs.
+func UnaryServerInterceptor(a *Auth, opts ...InterceptorOption) grpc.UnaryServerInterceptor {
+	cfg := newInterceptorConfig(opts)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if _, ok := cfg.publicMethods[info.FullMethod]; ok {
+			return handler(ctx, req)
+		}
+
+		ctx, err := a.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.policy != nil {
+			if permissions := cfg.policy(ctx, info.FullMethod); len(permissions) > 0 {
+				if err := a.CanI(ctx, permissions...); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart to
+// UnaryServerInterceptor.
+func StreamServerInterceptor(a *Auth, opts ...InterceptorOption) grpc.StreamServerInterceptor {
+	cfg := newInterceptorConfig(opts)
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if _, ok := cfg.publicMethods[info.FullMethod]; ok {
+			return handler(srv, ss)
+		}
+
+		ctx, err := a.authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+
+		if cfg.policy != nil {
+			if permissions := cfg.policy(ctx, info.FullMethod); len(permissions) > 0 {
+				if err := a.CanI(ctx, permissions...); err != nil {
+					return err
+				}
+			}
+		}
+
+		return handler(srv, &claimsServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// claimsServerStream overrides ServerStream.Context so handlers observe the
+// Claims authenticate populated, since grpc.ServerStream has no setter.
+type claimsServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *claimsServerStream) Context() context.Context { return s.ctx }
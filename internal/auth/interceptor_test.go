@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	rpcstatus "google.golang.org/grpc/status"
+)
+
+func newTestAuth() *Auth {
+	return &Auth{
+		aKey:      paseto.NewV4SymmetricKey(),
+		rKey:      paseto.NewV4SymmetricKey(),
+		revoker:   NewMemoryRevoker(),
+		roleCache: newRoleCache(),
+	}
+}
+
+func incomingCtxWithToken(token string) context.Context {
+	md := metadata.Pairs("authorization", "Bearer "+token)
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func TestAuthenticate_RevokedToken(t *testing.T) {
+	a := newTestAuth()
+	user := &User{ID: "u1", Username: "alice", DisplayName: "Alice"}
+
+	token, err := a.genToken(user)
+	if err != nil {
+		t.Fatalf("genToken() error = %v", err)
+	}
+
+	ctx := incomingCtxWithToken(token.Access)
+
+	authedCtx, err := a.authenticate(ctx)
+	if err != nil {
+		t.Fatalf("authenticate() error = %v", err)
+	}
+
+	claims := ClaimsFromContext(authedCtx)
+	if err := a.revoker.Revoke(context.Background(), claims.TokenID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+
+	if _, err := a.authenticate(ctx); err == nil {
+		t.Fatal("authenticate() error = nil for a revoked token")
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	a := newTestAuth()
+
+	RegisterScoper("orders:read", func(_ *Claims, _ json.RawMessage, _ any) error { return nil })
+	t.Cleanup(func() { delete(scopers, "orders:read") })
+
+	user := &User{ID: "u1", Username: "alice", DisplayName: "Alice"}
+
+	var handlerCalled bool
+	handler := func(ctx context.Context, req any) (any, error) {
+		handlerCalled = true
+		return "ok", nil
+	}
+
+	t.Run("public method bypasses authentication", func(t *testing.T) {
+		handlerCalled = false
+		interceptor := UnaryServerInterceptor(a, WithPublicMethods("/auth.Auth/Login"))
+
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/auth.Auth/Login"}, handler)
+		if err != nil {
+			t.Fatalf("interceptor() error = %v", err)
+		}
+		if !handlerCalled {
+			t.Fatal("handler was not called for a public method")
+		}
+	})
+
+	t.Run("missing token is rejected", func(t *testing.T) {
+		handlerCalled = false
+		interceptor := UnaryServerInterceptor(a)
+
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/auth.Auth/Profile"}, handler)
+		if rpcstatus.Code(err) != codes.Unauthenticated {
+			t.Fatalf("interceptor() code = %v, want Unauthenticated", rpcstatus.Code(err))
+		}
+		if handlerCalled {
+			t.Fatal("handler was called despite a missing token")
+		}
+	})
+
+	t.Run("valid token with no policy calls the handler", func(t *testing.T) {
+		handlerCalled = false
+		token, err := a.genToken(user)
+		if err != nil {
+			t.Fatalf("genToken() error = %v", err)
+		}
+
+		interceptor := UnaryServerInterceptor(a)
+		_, err = interceptor(incomingCtxWithToken(token.Access), nil, &grpc.UnaryServerInfo{FullMethod: "/auth.Auth/Profile"}, handler)
+		if err != nil {
+			t.Fatalf("interceptor() error = %v", err)
+		}
+		if !handlerCalled {
+			t.Fatal("handler was not called for a valid token with no policy")
+		}
+	})
+
+	t.Run("policy denies a scope without the required permission", func(t *testing.T) {
+		handlerCalled = false
+		token, err := a.genToken(user, ResourceScope{Resource: "orders:read", Verbs: []string{anyVerb}})
+		if err != nil {
+			t.Fatalf("genToken() error = %v", err)
+		}
+
+		interceptor := UnaryServerInterceptor(a, WithMethodPolicy(MethodPolicy{
+			"/auth.Auth/DeleteOrder": {"orders:write"},
+		}))
+		_, err = interceptor(incomingCtxWithToken(token.Access), nil, &grpc.UnaryServerInfo{FullMethod: "/auth.Auth/DeleteOrder"}, handler)
+		if rpcstatus.Code(err) != codes.PermissionDenied {
+			t.Fatalf("interceptor() code = %v, want PermissionDenied", rpcstatus.Code(err))
+		}
+		if handlerCalled {
+			t.Fatal("handler was called despite an out-of-scope permission")
+		}
+	})
+
+	t.Run("policy allows a scope with the required permission", func(t *testing.T) {
+		handlerCalled = false
+		token, err := a.genToken(user, ResourceScope{Resource: "orders:read", Verbs: []string{anyVerb}})
+		if err != nil {
+			t.Fatalf("genToken() error = %v", err)
+		}
+
+		interceptor := UnaryServerInterceptor(a, WithMethodPolicy(MethodPolicy{
+			"/auth.Auth/ListOrders": {"orders:read"},
+		}))
+		_, err = interceptor(incomingCtxWithToken(token.Access), nil, &grpc.UnaryServerInfo{FullMethod: "/auth.Auth/ListOrders"}, handler)
+		if err != nil {
+			t.Fatalf("interceptor() error = %v", err)
+		}
+		if !handlerCalled {
+			t.Fatal("handler was not called for an in-scope permission")
+		}
+	})
+}
+
+// fakeServerStream is a minimal grpc.ServerStream that only needs to carry a
+// context, for exercising claimsServerStream.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context { return s.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	a := newTestAuth()
+	user := &User{ID: "u1", Username: "alice", DisplayName: "Alice"}
+
+	token, err := a.genToken(user)
+	if err != nil {
+		t.Fatalf("genToken() error = %v", err)
+	}
+
+	ss := &fakeServerStream{ctx: incomingCtxWithToken(token.Access)}
+
+	var gotCtx context.Context
+	handler := func(srv any, stream grpc.ServerStream) error {
+		gotCtx = stream.Context()
+		return nil
+	}
+
+	interceptor := StreamServerInterceptor(a)
+	if err := interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/auth.Auth/Watch"}, handler); err != nil {
+		t.Fatalf("interceptor() error = %v", err)
+	}
+
+	if claims := ClaimsFromContext(gotCtx); claims.Username != "alice" {
+		t.Fatalf("claims.Username = %q, want %q", claims.Username, "alice")
+	}
+}
+
+func TestStreamServerInterceptor_PublicMethodBypassesAuthentication(t *testing.T) {
+	a := newTestAuth()
+
+	ss := &fakeServerStream{ctx: context.Background()}
+
+	var handlerCalled bool
+	handler := func(srv any, stream grpc.ServerStream) error {
+		handlerCalled = true
+		return nil
+	}
+
+	interceptor := StreamServerInterceptor(a, WithPublicMethods("/auth.Auth/Watch"))
+	if err := interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/auth.Auth/Watch"}, handler); err != nil {
+		t.Fatalf("interceptor() error = %v", err)
+	}
+	if !handlerCalled {
+		t.Fatal("handler was not called for a public method")
+	}
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	edpb "google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	rpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// loginAttemptWindow is how long a run of failures counts toward the
+	// same lockout; a failure outside the window starts a fresh count.
+	loginAttemptWindow = 15 * time.Minute
+	// maxLoginAttempts is how many failures within loginAttemptWindow
+	// trigger a lockout.
+	maxLoginAttempts = 5
+	// baseLockout is the lockout duration at the maxLoginAttempts-th
+	// failure; it doubles with every failure after that, up to maxLockout.
+	baseLockout = 30 * time.Second
+	maxLockout  = 24 * time.Hour
+)
+
+// dummyPasswordHash is verified, and discarded, whenever Login can't reach
+// a real password comparison (unknown username) so that branch costs the
+// same wall-clock time as a wrong password for a username that exists.
+var dummyPasswordHash = mustHashDummyPassword()
+
+func mustHashDummyPassword() string {
+	h, err := NewArgon2idHasher().Hash("this password is never checked against a real account")
+	if err != nil {
+		panic(err)
+	}
+
+	return h
+}
+
+// recordFailedLogin increments username's failure count, resetting it if
+// the current window began more than loginAttemptWindow ago, and returns how
+// long the account should stay locked once maxLoginAttempts is reached (0
+// if it isn't yet). The increment itself is a single atomic statement (see
+// incrementLoginAttempt) so concurrent failed logins can't undercount each
+// other's attempts.
+func (s *Auth) recordFailedLogin(ctx context.Context, username string) (time.Duration, error) {
+	count, _, err := incrementLoginAttempt(ctx, s.db, username, time.Now().Add(-loginAttemptWindow))
+	if err != nil {
+		return 0, err
+	}
+
+	if count < maxLoginAttempts {
+		return 0, nil
+	}
+
+	lockedUntil := time.Now().Add(loginAttemptBackoff(count))
+	if err := setLoginLockout(ctx, s.db, username, lockedUntil); err != nil {
+		return 0, err
+	}
+
+	return time.Until(lockedUntil), nil
+}
+
+// loginAttemptBackoff doubles baseLockout for every failure past
+// maxLoginAttempts, capped at maxLockout.
+func loginAttemptBackoff(count int) time.Duration {
+	shift := count - maxLoginAttempts
+	if shift > 11 { // avoid overflowing the shift before the maxLockout cap kicks in
+		return maxLockout
+	}
+
+	d := baseLockout << shift
+	if d <= 0 || d > maxLockout {
+		return maxLockout
+	}
+
+	return d
+}
+
+// invalidCredentialsError is returned for a failed login that hasn't
+// triggered a lockout.
+func (s *Auth) invalidCredentialsError() error {
+	st, err := rpcstatus.New(
+		codes.Unauthenticated,
+		"Your credentials not valid. Please check your username and password and try again.",
+	).WithDetails(&edpb.ErrorInfo{
+		Reason: "AUTH_INVALID_CREDENTIALS",
+		Domain: s.errorDomain,
+	})
+	if err != nil {
+		return rpcstatus.Error(codes.Unauthenticated, "Your credentials not valid. Please check your username and password and try again.")
+	}
+
+	return st.Err()
+}
+
+// lockedOutError is returned once maxLoginAttempts has been reached,
+// carrying retryAfter as a RetryInfo hint for well-behaved clients.
+func (s *Auth) lockedOutError(retryAfter time.Duration) error {
+	st, err := rpcstatus.New(
+		codes.ResourceExhausted,
+		"Too many failed login attempts. Please try again later.",
+	).WithDetails(
+		&edpb.ErrorInfo{
+			Reason: "AUTH_INVALID_CREDENTIALS",
+			Domain: s.errorDomain,
+		},
+		&edpb.RetryInfo{
+			RetryDelay: durationpb.New(retryAfter),
+		},
+	)
+	if err != nil {
+		return rpcstatus.Error(codes.ResourceExhausted, "Too many failed login attempts. Please try again later.")
+	}
+
+	return st.Err()
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginAttemptBackoff(t *testing.T) {
+	tests := []struct {
+		count int
+		want  time.Duration
+	}{
+		{count: maxLoginAttempts, want: baseLockout},
+		{count: maxLoginAttempts + 1, want: baseLockout * 2},
+		{count: maxLoginAttempts + 2, want: baseLockout * 4},
+		{count: maxLoginAttempts + 100, want: maxLockout},
+	}
+
+	for _, tt := range tests {
+		if got := loginAttemptBackoff(tt.count); got != tt.want {
+			t.Errorf("loginAttemptBackoff(%d) = %v, want %v", tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestLoginAttemptBackoff_NeverExceedsMaxLockout(t *testing.T) {
+	for count := maxLoginAttempts; count < maxLoginAttempts+1000; count++ {
+		if d := loginAttemptBackoff(count); d > maxLockout {
+			t.Fatalf("loginAttemptBackoff(%d) = %v, exceeds maxLockout %v", count, d, maxLockout)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// localMethod is the AuthManager.Name used when a LoginReq doesn't specify
+// one, preserving the previous bcrypt+pgx-only behavior.
+const localMethod = "local"
+
+// AuthManager authenticates a clientID/clientSecret pair against a single
+// identity source and returns the matching User on success. Auth.Login
+// dispatches to the manager named by LoginReq.Method, so deployments can
+// federate with an identity provider without forking this package.
+type AuthManager interface {
+	// Name is the value LoginReq.Method selects this manager with.
+	Name() string
+	// Authenticate verifies clientID/clientSecret and returns the User they
+	// belong to. It returns ErrUserNotFound when clientID is unknown.
+	Authenticate(ctx context.Context, clientID, clientSecret string) (*User, error)
+}
+
+// LocalManager authenticates against the users table, the behavior Login
+// had before AuthManager existed. A successful login whose stored hash
+// needsRehash (e.g. it's still bcrypt) is transparently re-hashed with the
+// configured PasswordHasher.
+type LocalManager struct {
+	db     *pgxpool.Pool
+	hasher PasswordHasher
+}
+
+// LocalManagerOption customizes a LocalManager built by NewLocalManager.
+type LocalManagerOption func(*LocalManager)
+
+// WithPasswordHasher overrides the PasswordHasher new passwords and
+// rehashes are minted with. It defaults to Argon2idHasher.
+func WithPasswordHasher(h PasswordHasher) LocalManagerOption {
+	return func(m *LocalManager) { m.hasher = h }
+}
+
+// NewLocalManager returns an AuthManager backed by db.
+func NewLocalManager(db *pgxpool.Pool, opts ...LocalManagerOption) *LocalManager {
+	m := &LocalManager{db: db, hasher: NewArgon2idHasher()}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+func (m *LocalManager) Name() string { return localMethod }
+
+func (m *LocalManager) Authenticate(ctx context.Context, clientID, clientSecret string) (*User, error) {
+	user, err := getUserByUsername(ctx, m.db, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, needsRehash, err := user.ComparePassword(clientSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify password: %w", err)
+	}
+	if !ok {
+		return nil, errors.New("credentials do not match")
+	}
+
+	if needsRehash {
+		encoded, err := m.hasher.Hash(clientSecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rehash password: %w", err)
+		}
+		if err := updatePasswordHash(ctx, m.db, user.Username, encoded); err != nil {
+			return nil, fmt.Errorf("failed to persist rehashed password: %w", err)
+		}
+	}
+
+	return user, nil
+}
+
+// WithAuthManager registers mgr so LoginReq.Method == mgr.Name() dispatches
+// to it. Registering a manager under a name that's already taken replaces
+// the previous one; New registers "local" after opts run, and only if no
+// option registered a manager under that name, so this is also how a
+// deployment would swap out LocalManager entirely.
+func WithAuthManager(mgr AuthManager) Option {
+	return func(a *Auth) { a.managers[mgr.Name()] = mgr }
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// JSONManager authenticates against a static username/password/display-name
+// list loaded from JSON, for local development and tests where standing up
+// Postgres or a real identity provider isn't worth it.
+type JSONManager struct {
+	name  string
+	users map[string]jsonUser
+}
+
+type jsonUser struct {
+	Password    string `json:"password"`
+	DisplayName string `json:"displayName"`
+}
+
+// NewJSONManager parses raw as a JSON object of username -> {password,
+// displayName} and returns a manager named name.
+func NewJSONManager(name string, raw []byte) (*JSONManager, error) {
+	users := make(map[string]jsonUser)
+	if err := json.Unmarshal(raw, &users); err != nil {
+		return nil, fmt.Errorf("failed to parse users: %w", err)
+	}
+
+	return &JSONManager{name: name, users: users}, nil
+}
+
+func (m *JSONManager) Name() string { return m.name }
+
+func (m *JSONManager) Authenticate(_ context.Context, clientID, clientSecret string) (*User, error) {
+	u, ok := m.users[clientID]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	if u.Password != clientSecret {
+		return nil, errors.New("credentials do not match")
+	}
+
+	return &User{
+		ID:          clientID,
+		Username:    clientID,
+		DisplayName: u.DisplayName,
+		Status:      StatusEnabled,
+	}, nil
+}
+
+// demoUsers is the fixed set of accounts NewDemoManager seeds. It is for
+// local development and tests only — never register this manager against a
+// production deployment.
+const demoUsers = `{
+	"demo": {"password": "demo", "displayName": "Demo User"}
+}`
+
+// NewDemoManager returns a JSONManager named "demo", seeded with a single
+// demo/demo account.
+func NewDemoManager() *JSONManager {
+	m, err := NewJSONManager("demo", []byte(demoUsers))
+	if err != nil {
+		panic(err)
+	}
+
+	return m
+}
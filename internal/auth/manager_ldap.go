@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAPManager authenticates by searching an LDAP directory for clientID and
+// binding as the matching entry with clientSecret.
+type LDAPManager struct {
+	addr            string
+	baseDN          string
+	userFilter      string
+	displayNameAttr string
+}
+
+// LDAPManagerConfig configures an LDAPManager. UserFilter defaults to
+// "(uid=%s)" and DisplayNameAttr to "displayName" when left empty.
+type LDAPManagerConfig struct {
+	// Addr is passed to ldap.DialURL, e.g. "ldaps://ldap.example.com:636".
+	Addr            string
+	BaseDN          string
+	UserFilter      string
+	DisplayNameAttr string
+}
+
+// NewLDAPManager returns an AuthManager backed by the directory in cfg.
+func NewLDAPManager(cfg LDAPManagerConfig) *LDAPManager {
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = "(uid=%s)"
+	}
+	if cfg.DisplayNameAttr == "" {
+		cfg.DisplayNameAttr = "displayName"
+	}
+
+	return &LDAPManager{
+		addr:            cfg.Addr,
+		baseDN:          cfg.BaseDN,
+		userFilter:      cfg.UserFilter,
+		displayNameAttr: cfg.DisplayNameAttr,
+	}
+}
+
+func (m *LDAPManager) Name() string { return "ldap" }
+
+func (m *LDAPManager) Authenticate(_ context.Context, clientID, clientSecret string) (*User, error) {
+	conn, err := ldap.DialURL(m.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial ldap server: %w", err)
+	}
+	defer conn.Close()
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		m.baseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 1, 0, false,
+		fmt.Sprintf(m.userFilter, ldap.EscapeFilter(clientID)),
+		[]string{"dn", m.displayNameAttr},
+		nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search ldap directory: %w", err)
+	}
+	if len(result.Entries) != 1 {
+		return nil, ErrUserNotFound
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, clientSecret); err != nil {
+		return nil, fmt.Errorf("failed to bind as %s: %w", entry.DN, err)
+	}
+
+	return &User{
+		ID:          entry.DN,
+		Username:    clientID,
+		DisplayName: entry.GetAttributeValue(m.displayNameAttr),
+		Status:      StatusEnabled,
+	}, nil
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+// OIDCManager authenticates by verifying an ID token issued by an external
+// OpenID Connect provider. clientSecret carries the ID token rather than a
+// password; clientID is only used as a fallback username when the token
+// carries no preferred_username claim.
+type OIDCManager struct {
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDCManager discovers issuerURL's provider metadata and returns an
+// AuthManager that verifies ID tokens were issued for clientID.
+func NewOIDCManager(ctx context.Context, issuerURL, clientID string) (*OIDCManager, error) {
+	provider, err := oidc.NewProvider(ctx, issuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover oidc provider: %w", err)
+	}
+
+	return &OIDCManager{verifier: provider.Verifier(&oidc.Config{ClientID: clientID})}, nil
+}
+
+func (m *OIDCManager) Name() string { return "oidc" }
+
+func (m *OIDCManager) Authenticate(ctx context.Context, clientID, clientSecret string) (*User, error) {
+	idToken, err := m.verifier.Verify(ctx, clientSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify id token: %w", err)
+	}
+
+	var claims struct {
+		Subject     string `json:"sub"`
+		Username    string `json:"preferred_username"`
+		DisplayName string `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to parse id token claims: %w", err)
+	}
+
+	username := claims.Username
+	if username == "" {
+		username = clientID
+	}
+
+	return &User{
+		ID:          claims.Subject,
+		Username:    username,
+		DisplayName: claims.DisplayName,
+		Status:      StatusEnabled,
+	}, nil
+}
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher hashes and verifies passwords using an algorithm-tagged
+// encoding, e.g. bcrypt's "$2a$..." or argon2id's "$argon2id$...". Hash is
+// used to mint new passwords; Verify checks a plaintext against whatever
+// encoding a stored password happens to carry, reporting needsRehash when
+// it was produced by a different algorithm or different parameters than
+// this hasher uses today.
+type PasswordHasher interface {
+	Hash(plain string) (string, error)
+	Verify(plain, encoded string) (ok, needsRehash bool, err error)
+}
+
+// argon2idPrefix tags the encoding produced by Argon2idHasher.
+const argon2idPrefix = "$argon2id$"
+
+// Argon2idHasher is the default PasswordHasher, tuned per the OWASP
+// password storage cheat sheet (1 iteration, 64 MiB, 4 lanes).
+type Argon2idHasher struct {
+	time    uint32
+	memory  uint32 // KiB
+	threads uint8
+	keyLen  uint32
+	saltLen uint32
+}
+
+// NewArgon2idHasher returns an Argon2idHasher using OWASP's recommended
+// parameters.
+func NewArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{
+		time:    1,
+		memory:  64 * 1024,
+		threads: 4,
+		keyLen:  32,
+		saltLen: 16,
+	}
+}
+
+func (h *Argon2idHasher) Hash(plain string) (string, error) {
+	salt := make([]byte, h.saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	hash := argon2.IDKey([]byte(plain), salt, h.time, h.memory, h.threads, h.keyLen)
+
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version, h.memory, h.time, h.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash),
+	), nil
+}
+
+func (h *Argon2idHasher) Verify(plain, encoded string) (ok, needsRehash bool, err error) {
+	params, salt, hash, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, false, err
+	}
+
+	candidate := argon2.IDKey([]byte(plain), salt, params.time, params.memory, params.threads, uint32(len(hash)))
+	if subtle.ConstantTimeCompare(candidate, hash) != 1 {
+		return false, false, nil
+	}
+
+	needsRehash = params.memory != h.memory || params.time != h.time || params.threads != h.threads
+
+	return true, needsRehash, nil
+}
+
+type argon2idParams struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+// decodeArgon2id parses the "$argon2id$v=..$m=..,t=..,p=..$salt$hash"
+// encoding Argon2idHasher.Hash produces.
+func decodeArgon2id(encoded string) (argon2idParams, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(encoded, argon2idPrefix), "$")
+	if len(parts) != 4 {
+		return argon2idParams{}, nil, nil, errors.New("invalid argon2id encoding")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[0], "v=%d", &version); err != nil {
+		return argon2idParams{}, nil, nil, fmt.Errorf("invalid argon2id version: %w", err)
+	}
+	if version != argon2.Version {
+		return argon2idParams{}, nil, nil, fmt.Errorf("unsupported argon2id version: %d", version)
+	}
+
+	var params argon2idParams
+	for _, field := range strings.Split(parts[1], ",") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return argon2idParams{}, nil, nil, errors.New("invalid argon2id parameters")
+		}
+
+		v, err := strconv.ParseUint(kv[1], 10, 32)
+		if err != nil {
+			return argon2idParams{}, nil, nil, fmt.Errorf("invalid argon2id parameter %q: %w", kv[0], err)
+		}
+
+		switch kv[0] {
+		case "m":
+			params.memory = uint32(v)
+		case "t":
+			params.time = uint32(v)
+		case "p":
+			params.threads = uint8(v)
+		}
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return argon2idParams{}, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+
+	hash, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return argon2idParams{}, nil, nil, fmt.Errorf("invalid argon2id hash: %w", err)
+	}
+
+	return params, salt, hash, nil
+}
+
+// BcryptHasher verifies the bcrypt encodings the module used before
+// Argon2idHasher became the default. Verify always reports needsRehash so a
+// successful login transparently migrates the user off bcrypt.
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher returns a BcryptHasher using bcrypt's default cost.
+func NewBcryptHasher() *BcryptHasher {
+	return &BcryptHasher{cost: bcrypt.DefaultCost}
+}
+
+func (h *BcryptHasher) Hash(plain string) (string, error) {
+	b, err := bcrypt.GenerateFromPassword([]byte(plain), h.cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+func (h *BcryptHasher) Verify(plain, encoded string) (ok, needsRehash bool, err error) {
+	err = bcrypt.CompareHashAndPassword([]byte(encoded), []byte(plain))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+
+	return true, true, nil
+}
+
+// verifyPassword dispatches to the PasswordHasher matching encoded's
+// algorithm prefix, independent of whichever hasher an Auth or LocalManager
+// is configured to mint new passwords with.
+func verifyPassword(plain, encoded string) (ok, needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(encoded, argon2idPrefix):
+		return NewArgon2idHasher().Verify(plain, encoded)
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return NewBcryptHasher().Verify(plain, encoded)
+	default:
+		return false, false, errors.New("unsupported password encoding")
+	}
+}
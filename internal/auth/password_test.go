@@ -0,0 +1,101 @@
+package auth
+
+import "testing"
+
+func TestArgon2idHasher_HashAndVerify(t *testing.T) {
+	h := NewArgon2idHasher()
+
+	encoded, err := h.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	ok, needsRehash, err := h.Verify("correct horse battery staple", encoded)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !ok {
+		t.Fatal("Verify() = false for the correct password")
+	}
+	if needsRehash {
+		t.Fatal("Verify() needsRehash = true for a hash minted with the hasher's own current parameters")
+	}
+
+	ok, _, err = h.Verify("wrong password", encoded)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if ok {
+		t.Fatal("Verify() = true for the wrong password")
+	}
+}
+
+func TestArgon2idHasher_Verify_NeedsRehash(t *testing.T) {
+	old := &Argon2idHasher{time: 1, memory: 16 * 1024, threads: 2, keyLen: 32, saltLen: 16}
+
+	encoded, err := old.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	ok, needsRehash, err := NewArgon2idHasher().Verify("correct horse battery staple", encoded)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !ok {
+		t.Fatal("Verify() = false for the correct password")
+	}
+	if !needsRehash {
+		t.Fatal("Verify() needsRehash = false for a hash minted with weaker parameters than the current default")
+	}
+}
+
+func TestBcryptHasher_HashAndVerify(t *testing.T) {
+	h := NewBcryptHasher()
+
+	encoded, err := h.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	ok, needsRehash, err := h.Verify("correct horse battery staple", encoded)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !ok {
+		t.Fatal("Verify() = false for the correct password")
+	}
+	if !needsRehash {
+		t.Fatal("Verify() needsRehash = false, but bcrypt should always migrate to the default hasher")
+	}
+
+	ok, _, err = h.Verify("wrong password", encoded)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if ok {
+		t.Fatal("Verify() = true for the wrong password")
+	}
+}
+
+func TestVerifyPassword_DispatchesByPrefix(t *testing.T) {
+	argon2Hash, err := NewArgon2idHasher().Hash("secret")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if ok, _, err := verifyPassword("secret", argon2Hash); err != nil || !ok {
+		t.Fatalf("verifyPassword(argon2id) = (%v, %v), want (true, nil)", ok, err)
+	}
+
+	bcryptHash, err := NewBcryptHasher().Hash("secret")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if ok, needsRehash, err := verifyPassword("secret", bcryptHash); err != nil || !ok || !needsRehash {
+		t.Fatalf("verifyPassword(bcrypt) = (%v, %v, %v), want (true, true, nil)", ok, needsRehash, err)
+	}
+
+	if _, _, err := verifyPassword("secret", "not a recognized encoding"); err == nil {
+		t.Fatal("verifyPassword() error = nil, want an error for an unrecognized encoding")
+	}
+}
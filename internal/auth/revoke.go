@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Revoker records tokens that must be rejected before their natural expiry,
+// keyed by the token's jti claim.
+type Revoker interface {
+	// Revoke marks jti as revoked until exp, after which it may be purged.
+	Revoke(ctx context.Context, jti string, exp time.Time) error
+	// IsRevoked reports whether jti has been revoked.
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// MemoryRevoker is an in-process Revoker, suitable for a single replica or
+// tests. Entries are never purged proactively; IsRevoked treats an expired
+// entry as not revoked.
+type MemoryRevoker struct {
+	mu   sync.RWMutex
+	jtis map[string]time.Time
+}
+
+// NewMemoryRevoker returns an empty in-memory Revoker.
+func NewMemoryRevoker() *MemoryRevoker {
+	return &MemoryRevoker{jtis: make(map[string]time.Time)}
+}
+
+func (r *MemoryRevoker) Revoke(_ context.Context, jti string, exp time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jtis[jti] = exp
+
+	return nil
+}
+
+func (r *MemoryRevoker) IsRevoked(_ context.Context, jti string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	exp, ok := r.jtis[jti]
+	if !ok {
+		return false, nil
+	}
+
+	return time.Now().Before(exp), nil
+}
+
+// PgxRevoker is a Revoker backed by the revoked_tokens table, so a
+// revocation is visible to every replica rather than just the one that
+// handled it.
+type PgxRevoker struct {
+	db *pgxpool.Pool
+}
+
+// NewPgxRevoker returns a Revoker backed by db.
+func NewPgxRevoker(db *pgxpool.Pool) *PgxRevoker {
+	return &PgxRevoker{db: db}
+}
+
+func (r *PgxRevoker) Revoke(ctx context.Context, jti string, exp time.Time) error {
+	q, args := sq.Insert("revoked_tokens").
+		Columns("jti", "exp").
+		Values(jti, exp).
+		Suffix("ON CONFLICT (jti) DO NOTHING").
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	_, err := r.db.Exec(ctx, q, args...)
+
+	return err
+}
+
+func (r *PgxRevoker) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	q, args := sq.Select("1").
+		From("revoked_tokens").
+		Where(sq.Eq{"jti": jti}).
+		Where(sq.Expr("exp > now()")).
+		PlaceholderFormat(sq.Dollar).
+		Limit(1).
+		MustSql()
+
+	var found int
+	err := r.db.QueryRow(ctx, q, args...).Scan(&found)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
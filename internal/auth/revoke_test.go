@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryRevoker(t *testing.T) {
+	ctx := context.Background()
+	r := NewMemoryRevoker()
+
+	revoked, err := r.IsRevoked(ctx, "jti-1")
+	if err != nil {
+		t.Fatalf("IsRevoked() error = %v", err)
+	}
+	if revoked {
+		t.Fatal("IsRevoked() = true for a jti that was never revoked")
+	}
+
+	if err := r.Revoke(ctx, "jti-1", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+
+	revoked, err = r.IsRevoked(ctx, "jti-1")
+	if err != nil {
+		t.Fatalf("IsRevoked() error = %v", err)
+	}
+	if !revoked {
+		t.Fatal("IsRevoked() = false right after Revoke()")
+	}
+}
+
+func TestMemoryRevoker_ExpiredEntry(t *testing.T) {
+	ctx := context.Background()
+	r := NewMemoryRevoker()
+
+	if err := r.Revoke(ctx, "jti-1", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+
+	revoked, err := r.IsRevoked(ctx, "jti-1")
+	if err != nil {
+		t.Fatalf("IsRevoked() error = %v", err)
+	}
+	if revoked {
+		t.Fatal("IsRevoked() = true for an entry whose exp has already passed")
+	}
+}
@@ -19,7 +19,7 @@ func (s *Auth) ListMyRoles(ctx context.Context) ([]*Role, error) {
 		zap.String("username", claims.Username),
 	)
 
-	roles, err := listRolesByUsername(ctx, s.db, claims.Username)
+	roles, err := s.rolesByUsername(ctx, claims.Username)
 	if err != nil {
 		zlog.Error("failed to list roles", zap.Error(err))
 		return nil, err
@@ -28,14 +28,29 @@ func (s *Auth) ListMyRoles(ctx context.Context) ([]*Role, error) {
 	return roles, nil
 }
 
-// CanI checks if the user has the specified permissions.
+// CanI checks if the user has the specified permissions. A token scoped
+// down by ResourceScope (see CanIDo) can never pass this check for a
+// permission outside its scopes, even if the underlying user holds the
+// role that grants it — the token, not just the user, must carry the
+// access.
 func (s *Auth) CanI(ctx context.Context, permissions ...string) error {
 	if len(permissions) == 0 {
 		return errors.New("no permissions specified")
 	}
 
 	claims := ClaimsFromContext(ctx)
-	roles, err := listRolesByUsername(ctx, s.db, claims.Username)
+
+	if !scopesGrantFullAccess(claims.Scopes) {
+		for _, p := range permissions {
+			if err := s.CanIDo(ctx, p, anyVerb, nil); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	roles, err := s.rolesByUsername(ctx, claims.Username)
 	if err != nil {
 		return err
 	}
@@ -51,6 +66,29 @@ func (s *Auth) CanI(ctx context.Context, permissions ...string) error {
 	return nil
 }
 
+// rolesByUsername returns username's roles, reusing the cached result for
+// the current auth_revision when possible so CanI and ListMyRoles don't hit
+// Postgres on every call.
+func (s *Auth) rolesByUsername(ctx context.Context, username string) ([]*Role, error) {
+	revision, err := getAuthRevision(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	if roles, ok := s.roleCache.get(username, revision); ok {
+		return roles, nil
+	}
+
+	roles, err := listRolesByUsername(ctx, s.db, username)
+	if err != nil {
+		return nil, err
+	}
+
+	s.roleCache.set(username, revision, roles)
+
+	return roles, nil
+}
+
 type Role struct {
 	ID          int64    `json:"id"`
 	Name        string   `json:"name"`
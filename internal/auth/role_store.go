@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// This file is the role/permission administration surface: creating and
+// deleting roles, granting/revoking permissions, and assigning roles to
+// users. Every mutation bumps auth_revision in the same transaction, so
+// rolesByUsername's cache observes it on the next call instead of serving a
+// stale result until it naturally expires.
+
+type CreateRoleReq struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// CreateRole creates a new role with no permissions.
+func (s *Auth) CreateRole(ctx context.Context, in *CreateRoleReq) (*Role, error) {
+	zlog := s.zlog.With(zap.String("Method", "CreateRole"), zap.Any("req", in))
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		zlog.Error("failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	role, err := createRole(ctx, tx, in)
+	if err != nil {
+		zlog.Error("failed to create role", zap.Error(err))
+		return nil, err
+	}
+
+	if err := bumpAuthRevision(ctx, tx); err != nil {
+		zlog.Error("failed to bump auth revision", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		zlog.Error("failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// DeleteRole deletes the role named name.
+func (s *Auth) DeleteRole(ctx context.Context, name string) error {
+	zlog := s.zlog.With(zap.String("Method", "DeleteRole"), zap.String("name", name))
+
+	return s.mutateAndBumpRevision(ctx, zlog, func(tx querier) error {
+		return deleteRole(ctx, tx, name)
+	})
+}
+
+// GrantPermission grants permissionName to roleName.
+func (s *Auth) GrantPermission(ctx context.Context, roleName, permissionName string) error {
+	zlog := s.zlog.With(
+		zap.String("Method", "GrantPermission"),
+		zap.String("role", roleName),
+		zap.String("permission", permissionName),
+	)
+
+	return s.mutateAndBumpRevision(ctx, zlog, func(tx querier) error {
+		return grantPermission(ctx, tx, roleName, permissionName)
+	})
+}
+
+// RevokePermission revokes permissionName from roleName.
+func (s *Auth) RevokePermission(ctx context.Context, roleName, permissionName string) error {
+	zlog := s.zlog.With(
+		zap.String("Method", "RevokePermission"),
+		zap.String("role", roleName),
+		zap.String("permission", permissionName),
+	)
+
+	return s.mutateAndBumpRevision(ctx, zlog, func(tx querier) error {
+		return revokePermission(ctx, tx, roleName, permissionName)
+	})
+}
+
+// AssignRole assigns roleName to username.
+func (s *Auth) AssignRole(ctx context.Context, username, roleName string) error {
+	zlog := s.zlog.With(
+		zap.String("Method", "AssignRole"),
+		zap.String("username", username),
+		zap.String("role", roleName),
+	)
+
+	return s.mutateAndBumpRevision(ctx, zlog, func(tx querier) error {
+		return assignRole(ctx, tx, username, roleName)
+	})
+}
+
+// UnassignRole removes roleName from username.
+func (s *Auth) UnassignRole(ctx context.Context, username, roleName string) error {
+	zlog := s.zlog.With(
+		zap.String("Method", "UnassignRole"),
+		zap.String("username", username),
+		zap.String("role", roleName),
+	)
+
+	return s.mutateAndBumpRevision(ctx, zlog, func(tx querier) error {
+		return unassignRole(ctx, tx, username, roleName)
+	})
+}
+
+// ListPermissions returns every permission known to the system.
+func (s *Auth) ListPermissions(ctx context.Context) ([]*Permission, error) {
+	zlog := s.zlog.With(zap.String("Method", "ListPermissions"))
+
+	permissions, err := listPermissions(ctx, s.db)
+	if err != nil {
+		zlog.Error("failed to list permissions", zap.Error(err))
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// mutateAndBumpRevision runs fn and bumps auth_revision inside a single
+// transaction, so the effect of fn is visible to rolesByUsername's cache
+// check as soon as the transaction commits.
+func (s *Auth) mutateAndBumpRevision(ctx context.Context, zlog *zap.Logger, fn func(tx querier) error) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		zlog.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		zlog.Error("failed to apply mutation", zap.Error(err))
+		return err
+	}
+
+	if err := bumpAuthRevision(ctx, tx); err != nil {
+		zlog.Error("failed to bump auth revision", zap.Error(err))
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		zlog.Error("failed to commit transaction", zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
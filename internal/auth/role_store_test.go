@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeQuerier is a hand-rolled querier for exercising the SQL helpers the
+// role/permission administration methods in role_store.go delegate to,
+// without a real Postgres.
+type fakeQuerier struct {
+	execFn     func(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	queryRowFn func(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func (f *fakeQuerier) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return f.execFn(ctx, sql, args...)
+}
+
+func (f *fakeQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return nil, errors.New("fakeQuerier: Query not implemented")
+}
+
+func (f *fakeQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return f.queryRowFn(ctx, sql, args...)
+}
+
+// fakeRow is a pgx.Row that scans back whatever values it was built with.
+type fakeRow struct {
+	values []any
+	err    error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	for i, d := range dest {
+		switch d := d.(type) {
+		case *int64:
+			*d = r.values[i].(int64)
+		case *string:
+			*d = r.values[i].(string)
+		default:
+			return errors.New("fakeRow: unsupported scan target")
+		}
+	}
+
+	return nil
+}
+
+func TestCreateRole(t *testing.T) {
+	db := &fakeQuerier{
+		queryRowFn: func(_ context.Context, _ string, _ ...any) pgx.Row {
+			return fakeRow{values: []any{int64(7), "owner", "Owner"}}
+		},
+	}
+
+	role, err := createRole(context.Background(), db, &CreateRoleReq{Name: "owner", DisplayName: "Owner"})
+	if err != nil {
+		t.Fatalf("createRole() error = %v", err)
+	}
+	if role.ID != 7 || role.Name != "owner" || role.DisplayName != "Owner" {
+		t.Fatalf("createRole() = %+v, want {ID:7 Name:owner DisplayName:Owner}", role)
+	}
+}
+
+func TestDeleteRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     string
+		wantErr error
+	}{
+		{name: "role exists", tag: "DELETE 1", wantErr: nil},
+		{name: "role does not exist", tag: "DELETE 0", wantErr: ErrRoleNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeQuerier{
+				execFn: func(_ context.Context, _ string, _ ...any) (pgconn.CommandTag, error) {
+					return pgconn.NewCommandTag(tt.tag), nil
+				},
+			}
+
+			err := deleteRole(context.Background(), db, "owner")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("deleteRole() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGrantAndRevokePermission(t *testing.T) {
+	var gotSQL string
+	var gotArgs []any
+	db := &fakeQuerier{
+		execFn: func(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+			gotSQL, gotArgs = sql, args
+			return pgconn.NewCommandTag("INSERT 0 1"), nil
+		},
+	}
+
+	if err := grantPermission(context.Background(), db, "owner", "invoice:read"); err != nil {
+		t.Fatalf("grantPermission() error = %v", err)
+	}
+	if gotArgs[0] != "owner" || gotArgs[1] != "invoice:read" {
+		t.Fatalf("grantPermission() args = %v, want [owner invoice:read]", gotArgs)
+	}
+	if gotSQL == "" {
+		t.Fatal("grantPermission() issued no SQL")
+	}
+
+	db.execFn = func(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+		gotSQL, gotArgs = sql, args
+		return pgconn.NewCommandTag("DELETE 1"), nil
+	}
+	if err := revokePermission(context.Background(), db, "owner", "invoice:read"); err != nil {
+		t.Fatalf("revokePermission() error = %v", err)
+	}
+	// sq.Eq built from a map orders its WHERE clause (and therefore its
+	// args) alphabetically by column name, not call-argument order.
+	if gotArgs[0] != "invoice:read" || gotArgs[1] != "owner" {
+		t.Fatalf("revokePermission() args = %v, want [invoice:read owner]", gotArgs)
+	}
+}
+
+func TestAssignAndUnassignRole(t *testing.T) {
+	var gotArgs []any
+	db := &fakeQuerier{
+		execFn: func(_ context.Context, _ string, args ...any) (pgconn.CommandTag, error) {
+			gotArgs = args
+			return pgconn.NewCommandTag("INSERT 0 1"), nil
+		},
+	}
+
+	if err := assignRole(context.Background(), db, "alice", "owner"); err != nil {
+		t.Fatalf("assignRole() error = %v", err)
+	}
+	if gotArgs[0] != "alice" || gotArgs[1] != "owner" {
+		t.Fatalf("assignRole() args = %v, want [alice owner]", gotArgs)
+	}
+
+	db.execFn = func(_ context.Context, _ string, args ...any) (pgconn.CommandTag, error) {
+		gotArgs = args
+		return pgconn.NewCommandTag("DELETE 1"), nil
+	}
+	if err := unassignRole(context.Background(), db, "alice", "owner"); err != nil {
+		t.Fatalf("unassignRole() error = %v", err)
+	}
+	// sq.Eq built from a map orders its WHERE clause (and therefore its
+	// args) alphabetically by column name, not call-argument order.
+	if gotArgs[0] != "owner" || gotArgs[1] != "alice" {
+		t.Fatalf("unassignRole() args = %v, want [owner alice]", gotArgs)
+	}
+}
+
+func TestGetAuthRevision_NoRowMeansZero(t *testing.T) {
+	db := &fakeQuerier{
+		queryRowFn: func(_ context.Context, _ string, _ ...any) pgx.Row {
+			return fakeRow{err: pgx.ErrNoRows}
+		},
+	}
+
+	revision, err := getAuthRevision(context.Background(), db)
+	if err != nil {
+		t.Fatalf("getAuthRevision() error = %v", err)
+	}
+	if revision != 0 {
+		t.Fatalf("getAuthRevision() = %d, want 0", revision)
+	}
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckMissingPermissions(t *testing.T) {
+	tests := []struct {
+		name   string
+		wanted []string
+		having []string
+		want   []string
+	}{
+		{
+			name:   "has everything wanted",
+			wanted: []string{"read", "write"},
+			having: []string{"read", "write", "delete"},
+			want:   []string{},
+		},
+		{
+			name:   "missing one",
+			wanted: []string{"read", "write"},
+			having: []string{"read"},
+			want:   []string{"write"},
+		},
+		{
+			name:   "missing all",
+			wanted: []string{"read", "write"},
+			having: []string{},
+			want:   []string{"read", "write"},
+		},
+		{
+			name:   "nothing wanted",
+			wanted: []string{},
+			having: []string{"read"},
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkMissingPermissions(tt.wanted, tt.having)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkMissingPermissions(%v, %v) = %v, want %v", tt.wanted, tt.having, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	rpcstatus "google.golang.org/grpc/status"
+)
+
+// Scope grants a token a bounded slice of what its subject may do. A token
+// carries one or more scopes in its "scopes" claim; CanIDo walks them until
+// one authorizes the request.
+type Scope interface {
+	// Type is the discriminator stored alongside the scope's payload so it
+	// can be decoded back into its concrete type.
+	Type() string
+}
+
+// UserScope grants the same unrestricted access a bare profile claim used to
+// imply. It is the default scope minted by Login and RefreshToken.
+type UserScope struct{}
+
+func (UserScope) Type() string { return "user" }
+
+// ResourceScope limits a token to a single resource and a set of verbs on
+// it, optionally expiring before the token itself does.
+type ResourceScope struct {
+	Resource string    `json:"resource"`
+	Verbs    []string  `json:"verbs"`
+	Expiry   time.Time `json:"expiry,omitempty"`
+}
+
+func (ResourceScope) Type() string { return "resource" }
+
+// anyVerb lets a ResourceScope authorize every verb on its Resource, so
+// CanI (which only knows permission names, not resource/verb pairs) can
+// still be expressed as a ResourceScope.
+const anyVerb = "*"
+
+func (s ResourceScope) allows(verb string) bool {
+	if !s.Expiry.IsZero() && time.Now().After(s.Expiry) {
+		return false
+	}
+
+	for _, v := range s.Verbs {
+		if v == verb || v == anyVerb {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scopesGrantFullAccess reports whether scopes include a UserScope, which
+// CanI treats as the unattenuated, role-based access a token had before
+// scopes existed. A token with no scopes at all is also treated as full
+// access, since genToken defaults to UserScope and this keeps pre-scope
+// tokens (without a "scopes" claim) working the same way.
+func scopesGrantFullAccess(scopes Scopes) bool {
+	if len(scopes) == 0 {
+		return true
+	}
+
+	for _, s := range scopes {
+		if _, ok := s.(UserScope); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Scopes is the JSON representation of a token's scopes claim. Each element
+// is wrapped with its Type() so UnmarshalJSON can reconstruct the right
+// concrete Scope.
+type Scopes []Scope
+
+type scopeEnvelope struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func (ss Scopes) MarshalJSON() ([]byte, error) {
+	envelopes := make([]scopeEnvelope, 0, len(ss))
+	for _, s := range ss {
+		payload, err := json.Marshal(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal scope: %w", err)
+		}
+
+		envelopes = append(envelopes, scopeEnvelope{Type: s.Type(), Payload: payload})
+	}
+
+	return json.Marshal(envelopes)
+}
+
+func (ss *Scopes) UnmarshalJSON(b []byte) error {
+	var envelopes []scopeEnvelope
+	if err := json.Unmarshal(b, &envelopes); err != nil {
+		return err
+	}
+
+	out := make(Scopes, 0, len(envelopes))
+	for _, e := range envelopes {
+		switch e.Type {
+		case (UserScope{}).Type():
+			out = append(out, UserScope{})
+
+		case (ResourceScope{}).Type():
+			var rs ResourceScope
+			if err := json.Unmarshal(e.Payload, &rs); err != nil {
+				return fmt.Errorf("failed to unmarshal resource scope: %w", err)
+			}
+			out = append(out, rs)
+
+		default:
+			return fmt.Errorf("unknown scope type: %s", e.Type)
+		}
+	}
+
+	*ss = out
+
+	return nil
+}
+
+// Scoper verifies whether a ResourceScope satisfies a request for its
+// resource. Callers register one per resource name; CanIDo dispatches to it
+// once it finds a scope whose Resource and Verbs match.
+type Scoper func(claims *Claims, scope json.RawMessage, req any) error
+
+var scopers = make(map[string]Scoper)
+
+// RegisterScoper registers the verifier CanIDo dispatches to for resource.
+// It is typically called from an init func in the package that owns the
+// resource.
+func RegisterScoper(resource string, fn Scoper) {
+	scopers[resource] = fn
+}
+
+// CanIDo checks whether the caller's token carries a scope that authorizes
+// verb on resource, passing req through to any Scoper registered for
+// resource. A UserScope always authorizes; a ResourceScope only authorizes
+// when its Resource and Verbs match, a Scoper is registered for resource,
+// and that Scoper approves the request. A token with no scopes is denied.
+func (s *Auth) CanIDo(ctx context.Context, resource, verb string, req any) error {
+	claims := ClaimsFromContext(ctx)
+
+	for _, scope := range claims.Scopes {
+		switch sc := scope.(type) {
+		case UserScope:
+			return nil
+
+		case ResourceScope:
+			if sc.Resource != resource || !sc.allows(verb) {
+				continue
+			}
+
+			// A resource with no registered Scoper can't be verified, so it
+			// must be denied rather than implicitly granted — otherwise a
+			// ResourceScope naming any unregistered resource would pass.
+			fn, ok := scopers[resource]
+			if !ok {
+				continue
+			}
+
+			payload, err := json.Marshal(sc)
+			if err != nil {
+				return fmt.Errorf("failed to marshal scope: %w", err)
+			}
+			if err := fn(claims, payload, req); err != nil {
+				continue
+			}
+
+			return nil
+		}
+	}
+
+	return rpcstatus.Error(
+		codes.PermissionDenied,
+		fmt.Sprintf("You do not have sufficient scope to %s %s", verb, resource),
+	)
+}
+
+// validateScopes rejects any ResourceScope in scopes that names a
+// permission username's roles don't actually grant, so Login can't be used
+// to self-mint a token scoped to access the user was never given. UserScope
+// needs no check: it only ever grants what a scope-less token already
+// grants by default.
+func (s *Auth) validateScopes(ctx context.Context, username string, scopes Scopes) error {
+	var permissions map[string]struct{}
+
+	for _, scope := range scopes {
+		rs, ok := scope.(ResourceScope)
+		if !ok {
+			continue
+		}
+
+		if permissions == nil {
+			roles, err := s.rolesByUsername(ctx, username)
+			if err != nil {
+				return err
+			}
+
+			permissions = make(map[string]struct{}, len(roles))
+			for _, p := range roleToPermissions(roles) {
+				permissions[p] = struct{}{}
+			}
+		}
+
+		if _, ok := permissions[rs.Resource]; !ok {
+			return rpcstatus.Error(
+				codes.PermissionDenied,
+				fmt.Sprintf("You do not have permission %q to scope a token to", rs.Resource),
+			)
+		}
+	}
+
+	return nil
+}
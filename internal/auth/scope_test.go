@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestScopes_JSONRoundTrip(t *testing.T) {
+	want := Scopes{
+		UserScope{},
+		ResourceScope{
+			Resource: "invoice",
+			Verbs:    []string{"read", "approve"},
+			Expiry:   time.Now().Add(time.Hour).Truncate(time.Second).UTC(),
+		},
+	}
+
+	b, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var got Scopes
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d scopes, want %d", len(got), len(want))
+	}
+	if _, ok := got[0].(UserScope); !ok {
+		t.Fatalf("got[0] = %T, want UserScope", got[0])
+	}
+
+	rs, ok := got[1].(ResourceScope)
+	if !ok {
+		t.Fatalf("got[1] = %T, want ResourceScope", got[1])
+	}
+	wantRS := want[1].(ResourceScope)
+	if rs.Resource != wantRS.Resource || !rs.Expiry.Equal(wantRS.Expiry) || len(rs.Verbs) != len(wantRS.Verbs) {
+		t.Fatalf("got[1] = %+v, want %+v", rs, wantRS)
+	}
+	for i, v := range rs.Verbs {
+		if v != wantRS.Verbs[i] {
+			t.Fatalf("got[1].Verbs = %v, want %v", rs.Verbs, wantRS.Verbs)
+		}
+	}
+}
+
+func TestScopes_UnmarshalJSON_UnknownType(t *testing.T) {
+	var got Scopes
+	err := json.Unmarshal([]byte(`[{"type":"bogus","payload":{}}]`), &got)
+	if err == nil {
+		t.Fatal("Unmarshal() error = nil, want an error for an unknown scope type")
+	}
+}
+
+func TestResourceScope_Allows(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope ResourceScope
+		verb  string
+		want  bool
+	}{
+		{
+			name:  "matching verb",
+			scope: ResourceScope{Resource: "invoice", Verbs: []string{"read"}},
+			verb:  "read",
+			want:  true,
+		},
+		{
+			name:  "non-matching verb",
+			scope: ResourceScope{Resource: "invoice", Verbs: []string{"read"}},
+			verb:  "delete",
+			want:  false,
+		},
+		{
+			name:  "wildcard verb",
+			scope: ResourceScope{Resource: "invoice", Verbs: []string{anyVerb}},
+			verb:  "delete",
+			want:  true,
+		},
+		{
+			name:  "expired",
+			scope: ResourceScope{Resource: "invoice", Verbs: []string{"read"}, Expiry: time.Now().Add(-time.Minute)},
+			verb:  "read",
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.scope.allows(tt.verb); got != tt.want {
+				t.Errorf("allows(%q) = %v, want %v", tt.verb, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopesGrantFullAccess(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes Scopes
+		want   bool
+	}{
+		{name: "no scopes (pre-scope token)", scopes: nil, want: true},
+		{name: "user scope", scopes: Scopes{UserScope{}}, want: true},
+		{
+			name: "user scope mixed with resource scope",
+			scopes: Scopes{
+				ResourceScope{Resource: "invoice", Verbs: []string{"read"}},
+				UserScope{},
+			},
+			want: true,
+		},
+		{
+			name:   "resource scope only",
+			scopes: Scopes{ResourceScope{Resource: "invoice", Verbs: []string{"read"}}},
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scopesGrantFullAccess(tt.scopes); got != tt.want {
+				t.Errorf("scopesGrantFullAccess() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,20 +9,48 @@ import (
 	"aidanwoods.dev/go-paseto"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 	edpb "google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	rpcstatus "google.golang.org/grpc/status"
 )
 
 type Auth struct {
-	db   *pgxpool.Pool
-	aKey paseto.V4SymmetricKey
-	rKey paseto.V4SymmetricKey
-	zlog *zap.Logger
+	db        *pgxpool.Pool
+	aKey      paseto.V4SymmetricKey
+	rKey      paseto.V4SymmetricKey
+	zlog      *zap.Logger
+	revoker   Revoker
+	roleCache *roleCache
+	managers  map[string]AuthManager
+	hasher    PasswordHasher
+	// errorDomain is attached as the Domain of the ErrorInfo detail on
+	// Login failures, e.g. "auth.example.com".
+	errorDomain string
 }
 
-func New(_ context.Context, db *pgxpool.Pool, zlog *zap.Logger, aKey, rKey paseto.V4SymmetricKey) (*Auth, error) {
+// Option customizes an Auth built by New.
+type Option func(*Auth)
+
+// WithRevoker overrides the default in-memory Revoker, e.g. with a
+// PgxRevoker so a revocation is visible across replicas.
+func WithRevoker(r Revoker) Option {
+	return func(a *Auth) { a.revoker = r }
+}
+
+// WithHasher overrides the PasswordHasher CreateUser and ChangePassword mint
+// new passwords with, and that the default "local" AuthManager rehashes
+// onto. It defaults to Argon2idHasher.
+func WithHasher(h PasswordHasher) Option {
+	return func(a *Auth) { a.hasher = h }
+}
+
+// WithErrorDomain sets the Domain attached to Login's ErrorInfo detail. It
+// defaults to "".
+func WithErrorDomain(domain string) Option {
+	return func(a *Auth) { a.errorDomain = domain }
+}
+
+func New(_ context.Context, db *pgxpool.Pool, zlog *zap.Logger, aKey, rKey paseto.V4SymmetricKey, opts ...Option) (*Auth, error) {
 	if db == nil {
 		return nil, errors.New("db is nil")
 	}
@@ -30,12 +58,172 @@ func New(_ context.Context, db *pgxpool.Pool, zlog *zap.Logger, aKey, rKey paset
 		return nil, errors.New("logger is nil")
 	}
 
-	return &Auth{
-		db:   db,
-		aKey: aKey,
-		rKey: rKey,
-		zlog: zlog,
-	}, nil
+	a := &Auth{
+		db:        db,
+		aKey:      aKey,
+		rKey:      rKey,
+		zlog:      zlog,
+		revoker:   NewMemoryRevoker(),
+		roleCache: newRoleCache(),
+		managers:  make(map[string]AuthManager),
+		hasher:    NewArgon2idHasher(),
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if _, ok := a.managers[localMethod]; !ok {
+		a.managers[localMethod] = NewLocalManager(db, WithPasswordHasher(a.hasher))
+	}
+
+	return a, nil
+}
+
+type CreateUserReq struct {
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	DisplayName string `json:"displayName"`
+}
+
+func (r *CreateUserReq) Validate() error {
+	violations := make([]*edpb.BadRequest_FieldViolation, 0)
+
+	if r.Username == "" {
+		violations = append(violations, &edpb.BadRequest_FieldViolation{
+			Field:       "username",
+			Description: "Username must not be empty",
+		})
+	}
+
+	if r.Password == "" {
+		violations = append(violations, &edpb.BadRequest_FieldViolation{
+			Field:       "password",
+			Description: "Password must not be empty",
+		})
+	}
+
+	if len(violations) > 0 {
+		s, _ := rpcstatus.New(
+			codes.InvalidArgument,
+			"User is not valid or incomplete. Please check the errors and try again, see details for more information.",
+		).WithDetails(&edpb.BadRequest{
+			FieldViolations: violations,
+		})
+
+		return s.Err()
+	}
+
+	return nil
+}
+
+// CreateUser provisions a new local user, hashing Password with the
+// configured PasswordHasher.
+func (s *Auth) CreateUser(ctx context.Context, in *CreateUserReq) (*User, error) {
+	zlog := s.zlog.With(
+		zap.String("Method", "CreateUser"),
+		zap.String("username", in.Username),
+	)
+
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+
+	encoded, err := s.hasher.Hash(in.Password)
+	if err != nil {
+		zlog.Error("failed to hash password", zap.Error(err))
+		return nil, err
+	}
+
+	user, err := createUser(ctx, s.db, in, encoded)
+	if err != nil {
+		zlog.Error("failed to create user", zap.Error(err))
+		return nil, err
+	}
+
+	return user, nil
+}
+
+type ChangePasswordReq struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+func (r *ChangePasswordReq) Validate() error {
+	violations := make([]*edpb.BadRequest_FieldViolation, 0)
+
+	if r.CurrentPassword == "" {
+		violations = append(violations, &edpb.BadRequest_FieldViolation{
+			Field:       "currentPassword",
+			Description: "Current password must not be empty",
+		})
+	}
+
+	if r.NewPassword == "" {
+		violations = append(violations, &edpb.BadRequest_FieldViolation{
+			Field:       "newPassword",
+			Description: "New password must not be empty",
+		})
+	}
+
+	if len(violations) > 0 {
+		s, _ := rpcstatus.New(
+			codes.InvalidArgument,
+			"Request is not valid or incomplete. Please check the errors and try again, see details for more information.",
+		).WithDetails(&edpb.BadRequest{
+			FieldViolations: violations,
+		})
+
+		return s.Err()
+	}
+
+	return nil
+}
+
+// ChangePassword re-hashes the caller's password with the configured
+// PasswordHasher after confirming CurrentPassword.
+func (s *Auth) ChangePassword(ctx context.Context, in *ChangePasswordReq) error {
+	claims := ClaimsFromContext(ctx)
+
+	zlog := s.zlog.With(
+		zap.String("Method", "ChangePassword"),
+		zap.String("username", claims.Username),
+	)
+
+	if err := in.Validate(); err != nil {
+		return err
+	}
+
+	user, err := getUserByUsername(ctx, s.db, claims.Username)
+	if errors.Is(err, ErrUserNotFound) {
+		return rpcstatus.Error(codes.PermissionDenied, "You are not allowed to access this resource or (it may not exist)")
+	}
+	if err != nil {
+		zlog.Error("failed to get user", zap.Error(err))
+		return err
+	}
+
+	ok, _, err := user.ComparePassword(in.CurrentPassword)
+	if err != nil {
+		zlog.Error("failed to verify current password", zap.Error(err))
+		return err
+	}
+	if !ok {
+		return rpcstatus.Error(codes.InvalidArgument, "Current password is not correct")
+	}
+
+	encoded, err := s.hasher.Hash(in.NewPassword)
+	if err != nil {
+		zlog.Error("failed to hash password", zap.Error(err))
+		return err
+	}
+
+	if err := updatePasswordHash(ctx, s.db, claims.Username, encoded); err != nil {
+		zlog.Error("failed to update password", zap.Error(err))
+		return err
+	}
+
+	return nil
 }
 
 func (s *Auth) Profile(ctx context.Context) (*User, error) {
@@ -59,32 +247,71 @@ func (s *Auth) Profile(ctx context.Context) (*User, error) {
 }
 
 func (s *Auth) Login(ctx context.Context, in *LoginReq) (*Token, error) {
+	method := in.Method
+	if method == "" {
+		method = localMethod
+	}
+
 	zlog := s.zlog.With(
 		zap.String("Method", "Login"),
 		zap.String("username", in.Username),
+		zap.String("authMethod", method),
 	)
 
 	if err := in.Validate(); err != nil {
 		return nil, err
 	}
 
-	user, err := getUserByUsername(ctx, s.db, in.Username)
-	if errors.Is(err, ErrUserNotFound) {
-		return nil, rpcstatus.Error(codes.Unauthenticated, "Your credentials not valid. Please check your username and password and try again.")
+	mgr, ok := s.managers[method]
+	if !ok {
+		return nil, rpcstatus.Error(codes.InvalidArgument, fmt.Sprintf("Unknown login method %q", method))
 	}
+
+	lockedUntil, err := getLoginLockout(ctx, s.db, in.Username)
 	if err != nil {
-		zlog.Error("failed to get user", zap.Error(err))
+		zlog.Error("failed to get login lockout", zap.Error(err))
 		return nil, err
 	}
+	if !lockedUntil.IsZero() && time.Now().Before(lockedUntil) {
+		return nil, s.lockedOutError(time.Until(lockedUntil))
+	}
 
-	if passed := user.ComparePassword(in.Password); !passed {
-		return nil, rpcstatus.Error(codes.Unauthenticated, "Your credentials not valid. Please check your username and password and try again.")
+	user, authErr := mgr.Authenticate(ctx, in.Username, in.Password)
+	if authErr == nil && !user.IsEnabled() {
+		authErr = errors.New("user is disabled")
 	}
-	if !user.IsEnabled() {
-		return nil, rpcstatus.Error(codes.Unauthenticated, "Your credentials not valid. Please check your token and try again.")
+
+	if authErr != nil {
+		if errors.Is(authErr, ErrUserNotFound) {
+			// mgr.Authenticate never reached a real hash comparison for an
+			// unknown username, so pay a dummy one here — otherwise this
+			// branch would be faster than a wrong password for a real user
+			// and wall-clock latency would reveal which case occurred.
+			_, _, _ = verifyPassword(in.Password, dummyPasswordHash)
+		} else {
+			zlog.Error("failed to authenticate", zap.Error(authErr))
+		}
+
+		lockout, err := s.recordFailedLogin(ctx, in.Username)
+		if err != nil {
+			zlog.Error("failed to record failed login", zap.Error(err))
+		}
+		if lockout > 0 {
+			return nil, s.lockedOutError(lockout)
+		}
+
+		return nil, s.invalidCredentialsError()
+	}
+
+	if err := resetLoginAttempts(ctx, s.db, in.Username); err != nil {
+		zlog.Error("failed to reset login attempts", zap.Error(err))
 	}
 
-	token, err := s.genToken(user)
+	if err := s.validateScopes(ctx, user.Username, in.Scopes); err != nil {
+		return nil, err
+	}
+
+	token, err := s.genToken(user, in.Scopes...)
 	if err != nil {
 		zlog.Error("failed to generate token", zap.Error(err))
 		return nil, err
@@ -123,6 +350,17 @@ func (s *Auth) RefreshToken(ctx context.Context, in *NewTokenReq) (*Token, error
 		return nil, rpcstatus.Error(codes.Unauthenticated, "Your credentials not valid. Please check your token and try again.")
 	}
 
+	if claims.TokenID != "" {
+		revoked, err := s.revoker.IsRevoked(ctx, claims.TokenID)
+		if err != nil {
+			zlog.Error("failed to check token revocation", zap.Error(err))
+			return nil, err
+		}
+		if revoked {
+			return nil, rpcstatus.Error(codes.Unauthenticated, "Your credentials not valid. Please check your token and try again.")
+		}
+	}
+
 	user, err := getUserByUsername(ctx, s.db, claims.Username)
 	if errors.Is(err, ErrUserNotFound) {
 		return nil, rpcstatus.Error(codes.Unauthenticated, "Your credentials not valid. Please check your token and try again.")
@@ -135,7 +373,7 @@ func (s *Auth) RefreshToken(ctx context.Context, in *NewTokenReq) (*Token, error
 		return nil, rpcstatus.Error(codes.Unauthenticated, "Your credentials not valid. Please check your token and try again.")
 	}
 
-	token, err := s.genToken(user)
+	token, err := s.genToken(user, claims.Scopes...)
 	if err != nil {
 		zlog.Error("failed to generate token", zap.Error(err))
 		return nil, err
@@ -144,7 +382,23 @@ func (s *Auth) RefreshToken(ctx context.Context, in *NewTokenReq) (*Token, error
 	return token, nil
 }
 
-func (s *Auth) genToken(u *User) (*Token, error) {
+// genToken mints an access/refresh token pair for u. When no scopes are
+// given the token carries a single UserScope, preserving the previous
+// full-access behavior.
+func (s *Auth) genToken(u *User, scopes ...Scope) (*Token, error) {
+	if len(scopes) == 0 {
+		scopes = []Scope{UserScope{}}
+	}
+
+	jti, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := u.toClaims()
+	claims.TokenID = jti
+	claims.Scopes = scopes
+
 	now := time.Now()
 
 	t := paseto.NewToken()
@@ -154,7 +408,7 @@ func (s *Auth) genToken(u *User) (*Token, error) {
 	t.SetExpiration(now.Add(time.Hour))
 	t.SetFooter([]byte(now.Format(time.RFC3339)))
 
-	if err := t.Set("profile", u.toClaims()); err != nil {
+	if err := t.Set("profile", claims); err != nil {
 		return nil, fmt.Errorf("failed to set claims: %w", err)
 	}
 
@@ -169,6 +423,17 @@ func (s *Auth) genToken(u *User) (*Token, error) {
 	}, nil
 }
 
+// Logout revokes the caller's current token so it can no longer be used to
+// refresh, even though it has not yet naturally expired.
+func (s *Auth) Logout(ctx context.Context) error {
+	claims := ClaimsFromContext(ctx)
+	if claims.TokenID == "" {
+		return nil
+	}
+
+	return s.revoker.Revoke(ctx, claims.TokenID, time.Now().Add(time.Hour*24*7))
+}
+
 type Token struct {
 	Access  string `json:"accessToken"`
 	Refresh string `json:"refreshToken"`
@@ -177,6 +442,13 @@ type Token struct {
 type LoginReq struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	// Method selects the AuthManager to authenticate against, by Name(). It
+	// defaults to "local" (the users table, via the configured hasher).
+	Method string `json:"method,omitempty"`
+	// Scopes attenuates the minted token to less than the caller's full
+	// access, e.g. a ResourceScope limited to a single resource and verbs.
+	// It defaults to a single UserScope (full access) when empty.
+	Scopes Scopes `json:"scopes,omitempty"`
 }
 
 func (r *LoginReq) Validate() error {
@@ -235,6 +507,9 @@ func (u *User) toClaims() *Claims {
 	}
 }
 
-func (u *User) ComparePassword(password string) bool {
-	return bcrypt.CompareHashAndPassword(u.hashedPassword, []byte(password)) == nil
+// ComparePassword reports whether password matches u's stored hash.
+// needsRehash is true when the stored hash was produced by bcrypt or by
+// argon2id parameters that differ from the package's current default.
+func (u *User) ComparePassword(password string) (ok, needsRehash bool, err error) {
+	return verifyPassword(password, string(u.hashedPassword))
 }
@@ -4,17 +4,26 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/lib/pq"
 )
 
 // ErrUserNotFound is returned when a user is not found.
 var ErrUserNotFound = errors.New("user not found")
 
-func getUserByUsername(ctx context.Context, db *pgxpool.Pool, username string) (*User, error) {
+// querier is satisfied by both *pgxpool.Pool and pgx.Tx, so query helpers
+// can run standalone or as part of a larger transaction.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func getUserByUsername(ctx context.Context, db querier, username string) (*User, error) {
 	q, args := sq.Select(
 		"id",
 		"username",
@@ -57,7 +66,7 @@ func getUserByUsername(ctx context.Context, db *pgxpool.Pool, username string) (
 	return u, nil
 }
 
-func listRolesByUsername(ctx context.Context, db *pgxpool.Pool, username string) ([]*Role, error) {
+func listRolesByUsername(ctx context.Context, db querier, username string) ([]*Role, error) {
 	query, args := sq.Select(
 		"r.id",
 		"r.name",
@@ -107,3 +116,271 @@ func listRolesByUsername(ctx context.Context, db *pgxpool.Pool, username string)
 
 	return rs, nil
 }
+
+// getLoginLockout returns the username's current locked_until, the zero
+// time if the account has never been locked (or has no row at all); a
+// lockout that has already passed is returned as is. It's a plain read used
+// only for Login's up-front check, so it doesn't need FOR UPDATE:
+// incrementLoginAttempt is the one place that increments the counter, and
+// it does so atomically.
+func getLoginLockout(ctx context.Context, db querier, username string) (time.Time, error) {
+	q, args := sq.Select("locked_until").
+		From("login_attempts").
+		Where(sq.Eq{"username": username}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	var lockedUntil *time.Time
+	err := db.QueryRow(ctx, q, args...).Scan(&lockedUntil)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query login lockout: %w", err)
+	}
+	if lockedUntil == nil {
+		return time.Time{}, nil
+	}
+
+	return *lockedUntil, nil
+}
+
+// incrementLoginAttempt atomically increments username's failure count and
+// returns the new value, starting a fresh window (count 1) if the current
+// window started before windowCutoff. The INSERT ... ON CONFLICT performs the
+// read, reset-or-increment, and write as a single statement under the row's
+// lock, so concurrent failed logins for the same username can't race each
+// other the way a separate SELECT then UPDATE would.
+func incrementLoginAttempt(ctx context.Context, db querier, username string, windowCutoff time.Time) (count int, windowStartedAt time.Time, err error) {
+	const q = `
+INSERT INTO login_attempts (username, count, window_started_at)
+VALUES ($1, 1, now())
+ON CONFLICT (username) DO UPDATE SET
+	count = CASE WHEN login_attempts.window_started_at < $2 THEN 1 ELSE login_attempts.count + 1 END,
+	window_started_at = CASE WHEN login_attempts.window_started_at < $2 THEN now() ELSE login_attempts.window_started_at END
+RETURNING count, window_started_at`
+
+	if err := db.QueryRow(ctx, q, username, windowCutoff).Scan(&count, &windowStartedAt); err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to increment login attempt: %w", err)
+	}
+
+	return count, windowStartedAt, nil
+}
+
+func setLoginLockout(ctx context.Context, db querier, username string, lockedUntil time.Time) error {
+	q, args := sq.Update("login_attempts").
+		Set("locked_until", lockedUntil).
+		Where(sq.Eq{"username": username}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	if _, err := db.Exec(ctx, q, args...); err != nil {
+		return fmt.Errorf("failed to set login lockout: %w", err)
+	}
+
+	return nil
+}
+
+func resetLoginAttempts(ctx context.Context, db querier, username string) error {
+	q, args := sq.Delete("login_attempts").
+		Where(sq.Eq{"username": username}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	if _, err := db.Exec(ctx, q, args...); err != nil {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+
+	return nil
+}
+
+func createUser(ctx context.Context, db querier, in *CreateUserReq, hashedPassword string) (*User, error) {
+	q, args := sq.Insert("users").
+		Columns("username", "display_name", "hash_password", "status").
+		Values(in.Username, in.DisplayName, hashedPassword, StatusEnabled).
+		Suffix("RETURNING id, username, display_name, status, created_at").
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	u := new(User)
+	if err := db.QueryRow(ctx, q, args...).Scan(&u.ID, &u.Username, &u.DisplayName, &u.Status, &u.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to insert user: %w", err)
+	}
+
+	return u, nil
+}
+
+func updatePasswordHash(ctx context.Context, db querier, username, hashedPassword string) error {
+	q, args := sq.Update("users").
+		Set("hash_password", hashedPassword).
+		Where(sq.Eq{"username": username}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	if _, err := db.Exec(ctx, q, args...); err != nil {
+		return fmt.Errorf("failed to update password hash: %w", err)
+	}
+
+	return nil
+}
+
+// ErrRoleNotFound is returned when a role is not found.
+var ErrRoleNotFound = errors.New("role not found")
+
+func createRole(ctx context.Context, db querier, in *CreateRoleReq) (*Role, error) {
+	q, args := sq.Insert("role").
+		Columns("name", "display_name").
+		Values(in.Name, in.DisplayName).
+		Suffix("RETURNING id, name, display_name").
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	r := new(Role)
+	if err := db.QueryRow(ctx, q, args...).Scan(&r.ID, &r.Name, &r.DisplayName); err != nil {
+		return nil, fmt.Errorf("failed to insert role: %w", err)
+	}
+
+	return r, nil
+}
+
+func deleteRole(ctx context.Context, db querier, name string) error {
+	q, args := sq.Delete("role").
+		Where(sq.Eq{"name": name}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	tag, err := db.Exec(ctx, q, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRoleNotFound
+	}
+
+	return nil
+}
+
+func grantPermission(ctx context.Context, db querier, roleName, permissionName string) error {
+	q, args := sq.Insert("role_has_permission").
+		Columns("role_name", "permission_name").
+		Values(roleName, permissionName).
+		Suffix("ON CONFLICT (role_name, permission_name) DO NOTHING").
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	if _, err := db.Exec(ctx, q, args...); err != nil {
+		return fmt.Errorf("failed to grant permission: %w", err)
+	}
+
+	return nil
+}
+
+func revokePermission(ctx context.Context, db querier, roleName, permissionName string) error {
+	q, args := sq.Delete("role_has_permission").
+		Where(sq.Eq{"role_name": roleName, "permission_name": permissionName}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	if _, err := db.Exec(ctx, q, args...); err != nil {
+		return fmt.Errorf("failed to revoke permission: %w", err)
+	}
+
+	return nil
+}
+
+func assignRole(ctx context.Context, db querier, username, roleName string) error {
+	q, args := sq.Insert("user_has_role").
+		Columns("username", "role_name").
+		Values(username, roleName).
+		Suffix("ON CONFLICT (username, role_name) DO NOTHING").
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	if _, err := db.Exec(ctx, q, args...); err != nil {
+		return fmt.Errorf("failed to assign role: %w", err)
+	}
+
+	return nil
+}
+
+func unassignRole(ctx context.Context, db querier, username, roleName string) error {
+	q, args := sq.Delete("user_has_role").
+		Where(sq.Eq{"username": username, "role_name": roleName}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	if _, err := db.Exec(ctx, q, args...); err != nil {
+		return fmt.Errorf("failed to unassign role: %w", err)
+	}
+
+	return nil
+}
+
+func listPermissions(ctx context.Context, db querier) ([]*Permission, error) {
+	q, args := sq.Select("id", "name", "display_name").
+		From("permission").
+		OrderBy("name").
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	rows, err := db.Query(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	ps := make([]*Permission, 0)
+	for rows.Next() {
+		p := new(Permission)
+		if err := rows.Scan(&p.ID, &p.Name, &p.DisplayName); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		ps = append(ps, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return ps, nil
+}
+
+// getAuthRevision returns the current value of the single-row auth_revision
+// table, or 0 if the row doesn't exist yet (bumpAuthRevision creates it on
+// the first mutation).
+func getAuthRevision(ctx context.Context, db querier) (int64, error) {
+	q, args := sq.Select("value").
+		From("auth_revision").
+		Where(sq.Eq{"id": 1}).
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	var revision int64
+	err := db.QueryRow(ctx, q, args...).Scan(&revision)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to query auth revision: %w", err)
+	}
+
+	return revision, nil
+}
+
+// bumpAuthRevision increments the auth_revision row, inserting it if this is
+// the first mutation. Callers run it inside the same transaction as the
+// mutation so CanI and ListMyRoles observe the new revision atomically.
+func bumpAuthRevision(ctx context.Context, db querier) error {
+	q, args := sq.Insert("auth_revision").
+		Columns("id", "value").
+		Values(1, 1).
+		Suffix("ON CONFLICT (id) DO UPDATE SET value = auth_revision.value + 1").
+		PlaceholderFormat(sq.Dollar).
+		MustSql()
+
+	if _, err := db.Exec(ctx, q, args...); err != nil {
+		return fmt.Errorf("failed to bump auth revision: %w", err)
+	}
+
+	return nil
+}